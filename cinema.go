@@ -1,7 +1,7 @@
 package cinema
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -9,7 +9,6 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -18,16 +17,28 @@ import (
 // transformation functions to generate the desired output. Then call Render to
 // generate the final output video file.
 type Video struct {
-	filepath       string
-	width          int
-	height         int
-	fps            int
-	bitrate        int
-	start          time.Duration
-	end            time.Duration
-	duration       time.Duration
-	filters        []string
-	additionalArgs []string
+	filepath        string
+	width           int
+	height          int
+	fps             int
+	bitrate         int
+	start           time.Duration
+	end             time.Duration
+	duration        time.Duration
+	filters         FilterGraph
+	additionalArgs  []string
+	progressFunc    ProgressFunc
+	hwaccel         string
+	vcodec          string
+	acodec          string
+	overlayPath     string
+	overlayX        int
+	overlayY        int
+	chapters        []chapterEntry
+	chapterMetaPath string
+	subtitlePath    string
+	subtitleLang    string
+	ffmpegPath      string
 }
 
 // Clip contains the absolute or relative path to video files that shall be concatenated.
@@ -36,13 +47,14 @@ type Video struct {
 type Clip struct {
 	videosPath      []string
 	concatListCache string
+	ffmpegPath      string
 }
 
 // Load gives you a Video that can be operated on. Load does not open the file
 // or load it into memory. Apply operations to the Video and call Render to
 // generate the output video file.
 func Load(path string) (*Video, error) {
-	if _, err := exec.LookPath("ffprobe"); err != nil {
+	if _, err := exec.LookPath(locateFFprobe()); err != nil {
 		return nil, errors.New("cinema.Load: ffprobe was not found in your PATH " +
 			"environment variable, make sure to install ffmpeg " +
 			"(https://ffmpeg.org/) and add ffmpeg, ffplay and ffprobe to your " +
@@ -53,80 +65,33 @@ func Load(path string) (*Video, error) {
 		return nil, errors.New("cinema.Load: unable to load file: " + err.Error())
 	}
 
-	cmd := exec.Command(
-		"ffprobe",
-		"-v", "quiet",
-		"-print_format", "json",
-		"-show_format",
-		"-show_streams",
-		path,
-	)
-	out, err := cmd.Output()
-
+	mi, err := Probe(path)
 	if err != nil {
-		return nil, errors.New("cinema.Load: ffprobe failed: " + err.Error())
-	}
-
-	type description struct {
-		Streams []struct {
-			Width  int `json:"width"`
-			Height int `json:"height"`
-			Tags   struct {
-				// Rotation is optional -> use a pointer.
-				Rotation *json.Number `json:"rotate"`
-			} `json:"tags"`
-		} `json:"streams"`
-		Format struct {
-			DurationSec json.Number `json:"duration"`
-			Bitrate     json.Number `json:"bit_rate"`
-		} `json:"format"`
-	}
-	var desc description
-	if err := json.Unmarshal(out, &desc); err != nil {
-		return nil, errors.New("cinema.Load: unable to parse JSON output " +
-			"from ffprobe: " + err.Error())
-	}
-	if len(desc.Streams) == 0 {
-		return nil, errors.New("cinema.Load: ffprobe does not contain stream " +
-			"data, make sure the file " + path + " contains a valid video.")
-	}
-
-	secs, err := desc.Format.DurationSec.Float64()
-	if err != nil {
-		return nil, errors.New("cinema.Load: ffprobe returned invalid duration: " +
-			err.Error())
+		return nil, errors.New("cinema.Load: " + err.Error())
 	}
-	bitrate, err := desc.Format.Bitrate.Int64()
-	if err != nil {
-		return nil, errors.New("cinema.Load: ffprobe returned invalid duration: " +
-			err.Error())
-	}
-
-	// Round seconds (floating point value) up to time.Duration. seconds will
-	// be >= 0 so adding 0.5 rounds to the right integer Duration value.
-	duration := time.Duration(secs*float64(time.Second) + 0.5)
 
-	dsIndex := 0
-	for index, v := range desc.Streams {
-		if v.Width != 0 && v.Height != 0 {
-			dsIndex = index
-			break
-		}
+	vs := mi.VideoStream()
+	if vs == nil || vs.Width <= 0 || vs.Height <= 0 {
+		return nil, errors.New("cinema.Load: ffprobe does not report a video " +
+			"stream with valid dimensions, make sure the file " + path +
+			" actually contains the video format it claims to.")
+	}
+	if mi.Format.Duration <= 0 {
+		return nil, errors.New("cinema.Load: ffprobe reports a non-positive " +
+			"duration, make sure the file " + path + " contains a valid video.")
 	}
 
-	width := desc.Streams[dsIndex].Width
-	height := desc.Streams[dsIndex].Height
-	if desc.Streams[dsIndex].Tags.Rotation != nil {
-		// If the video is rotated by -270, -90, 90 or 270 degrees, we need to
-		// flip the width and height because they will be reported in unrotated
-		// coordinates while cropping etc. works on the rotated dimensions.
-		rotation, err := desc.Streams[dsIndex].Tags.Rotation.Int64()
+	width, height := vs.Width, vs.Height
+	if rotate, ok := vs.Tags["rotate"]; ok && rotate != "" {
+		rotation, err := strconv.ParseInt(rotate, 10, 64)
 		if err != nil {
 			return nil, errors.New("cinema.Load: ffprobe returned invalid " +
 				"rotation: " + err.Error())
 		}
-		flipCount := rotation / 90
-		if flipCount%2 != 0 {
+		// If the video is rotated by -270, -90, 90 or 270 degrees, we need to
+		// flip the width and height because they will be reported in unrotated
+		// coordinates while cropping etc. works on the rotated dimensions.
+		if flipCount := rotation / 90; flipCount%2 != 0 {
 			width, height = height, width
 		}
 	}
@@ -136,10 +101,10 @@ func Load(path string) (*Video, error) {
 		width:    width,
 		height:   height,
 		fps:      30,
-		bitrate:  int(bitrate),
+		bitrate:  int(mi.Format.BitRate),
 		start:    0,
-		end:      duration,
-		duration: duration,
+		end:      mi.Format.Duration,
+		duration: mi.Format.Duration,
 	}, nil
 }
 
@@ -150,43 +115,166 @@ func (v *Video) Render(output string) error {
 	return v.RenderWithStreams(output, nil, nil)
 }
 
+// RenderContext is like Render but the render can be cancelled, or timed out,
+// via ctx. Once ctx is done, the underlying ffmpeg process is killed and
+// RenderContext returns an error wrapping ctx.Err().
+func (v *Video) RenderContext(ctx context.Context, output string) error {
+	return v.RenderWithStreamsContext(ctx, output, nil, nil)
+}
+
 // RenderWithStreams applies all operations to the Video and creates an output video file
 // of the given name. By specifying an output stream and an error stream, you can read
 // ffmpeg's stdout and stderr.
 func (v *Video) RenderWithStreams(output string, os io.Writer, es io.Writer) error {
+	return v.RenderWithStreamsContext(context.Background(), output, os, es)
+}
+
+// RenderWithStreamsContext combines RenderWithStreams and RenderContext: the
+// render can be cancelled via ctx, and ffmpeg's stdout/stderr are forwarded
+// to os/es. If a ProgressFunc has been set via SetProgressFunc, ffmpeg's
+// stdout is instead consumed internally to parse "-progress" updates and os
+// is not written to.
+func (v *Video) RenderWithStreamsContext(ctx context.Context, output string, os io.Writer, es io.Writer) error {
+	if len(v.chapters) > 0 {
+		if err := v.saveChapterMetadata(); err != nil {
+			return err
+		}
+		defer v.deleteChapterMetadata()
+	}
+
 	line := v.CommandLine(output)
-	cmd := exec.Command(line[0], line[1:]...)
+	cmd := exec.CommandContext(ctx, line[0], line[1:]...)
 	cmd.Stderr = es
-	cmd.Stdout = os
 
-	err := cmd.Run()
+	if v.progressFunc == nil {
+		cmd.Stdout = os
+		if err := cmd.Run(); err != nil {
+			return wrapContextErr(ctx, "cinema.Video.Render", err)
+		}
+		return nil
+	}
+
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return errors.New("cinema.Video.Render: ffmpeg failed: " + err.Error())
+		return errors.New("cinema.Video.Render: unable to open stdout pipe: " + err.Error())
+	}
+	if err := cmd.Start(); err != nil {
+		return wrapContextErr(ctx, "cinema.Video.Render", err)
+	}
+	watchProgress(stdout, v.end-v.start, v.progressFunc)
+	if err := cmd.Wait(); err != nil {
+		return wrapContextErr(ctx, "cinema.Video.Render", err)
 	}
 	return nil
 }
 
+// wrapContextErr turns a failed command's error into one a caller can detect
+// cancellation/timeout from via errors.Is(err, context.Canceled) or
+// errors.Is(err, context.DeadlineExceeded): if ctx is done, that's almost
+// certainly why the command failed (exec.CommandContext kills the process on
+// ctx.Done), so ctx.Err() is wrapped instead of the opaque "signal: killed"
+// *exec.ExitError. Otherwise the original ffmpeg failure is preserved.
+func wrapContextErr(ctx context.Context, prefix string, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%s: %w", prefix, ctxErr)
+	}
+	return errors.New(prefix + ": ffmpeg failed: " + err.Error())
+}
+
 // CommandLine returns the command line that will be used to convert the Video
 // if you were to call Render.
 func (v *Video) CommandLine(output string) []string {
-	var filters string
-	if len(v.filters) > 0 {
-		filters = strings.Join(v.filters, ",") + ","
+	videoFilters := v.filters.VideoChain()
+	if videoFilters != "" {
+		videoFilters += ","
+	}
+	videoFilters += "setsar=1,fps=fps=" + strconv.Itoa(int(v.fps))
+	audioFilters := v.filters.AudioChain()
+
+	// Extra inputs (overlay image/video, subtitle track to soft-mux, chapter
+	// metadata file) are appended after the main input, in that order, so
+	// their ffmpeg input index is 1 + their position here.
+	var extraInputs []string
+	overlayIndex, subtitleIndex, chapterIndex := -1, -1, -1
+	if v.overlayPath != "" {
+		extraInputs = append(extraInputs, v.overlayPath)
+		overlayIndex = len(extraInputs)
+	}
+	if v.subtitlePath != "" {
+		extraInputs = append(extraInputs, v.subtitlePath)
+		subtitleIndex = len(extraInputs)
+	}
+	if v.chapterMetaPath != "" {
+		extraInputs = append(extraInputs, v.chapterMetaPath)
+		chapterIndex = len(extraInputs)
 	}
-	filters += "setsar=1,fps=fps=" + strconv.Itoa(int(v.fps))
-
-	additionalArgs := v.additionalArgs
 
-	cmdline := []string{
-		"ffmpeg",
-		"-y",
-		"-i", v.filepath,
+	cfg := getConfig()
+	cmdline := []string{locateFFmpeg(v.ffmpegPath), "-y"}
+	if cfg.LogLevel != "" {
+		cmdline = append(cmdline, "-v", cfg.LogLevel)
+	}
+	cmdline = append(cmdline, cfg.DefaultArgs...)
+	if v.hwaccel != "" {
+		cmdline = append(cmdline, "-hwaccel", v.hwaccel)
+		switch v.hwaccel {
+		case "cuda":
+			cmdline = append(cmdline, "-hwaccel_output_format", "cuda")
+		case "vaapi":
+			// Without this, vaapi decode output lands in system memory and
+			// scale_vaapi (hwaccelFilterSuffix) fails at runtime trying to
+			// convert it.
+			cmdline = append(cmdline, "-hwaccel_output_format", "vaapi")
+		}
+	}
+	cmdline = append(cmdline, "-i", v.filepath)
+	for _, input := range extraInputs {
+		cmdline = append(cmdline, "-i", input)
+	}
+	cmdline = append(cmdline,
 		"-ss", strconv.FormatFloat(v.start.Seconds(), 'f', -1, 64),
 		"-t", strconv.FormatFloat((v.end - v.start).Seconds(), 'f', -1, 64),
 		"-vb", strconv.Itoa(v.bitrate),
+	)
+	cmdline = append(cmdline, v.additionalArgs...)
+
+	// videoMapArgs selects the processed video (and, for overlay, the
+	// original audio) stream. It's only appended when another explicit -map
+	// (subtitle/chapter below) would otherwise disable ffmpeg's automatic
+	// stream selection and leave the overlay's filter_complex output
+	// unmapped, competing with the raw "-map 0" video stream.
+	var videoMapArgs []string
+	if overlayIndex != -1 {
+		filterComplex := fmt.Sprintf("[0:v]%s[base];[base][%d:v]overlay=%d:%d[vout]", videoFilters, overlayIndex, v.overlayX, v.overlayY)
+		cmdline = append(cmdline, "-filter_complex", filterComplex)
+		videoMapArgs = []string{"-map", "[vout]", "-map", "0:a?"}
+	} else {
+		cmdline = append(cmdline, "-vf", videoFilters)
+		videoMapArgs = []string{"-map", "0"}
+	}
+	if audioFilters != "" {
+		cmdline = append(cmdline, "-af", audioFilters)
+	}
+	if subtitleIndex != -1 || chapterIndex != -1 {
+		cmdline = append(cmdline, videoMapArgs...)
+	}
+	if subtitleIndex != -1 {
+		cmdline = append(cmdline, "-map", strconv.Itoa(subtitleIndex),
+			"-c:s", "mov_text", "-metadata:s:s:0", "language="+v.subtitleLang)
+	}
+	if chapterIndex != -1 {
+		cmdline = append(cmdline, "-map_metadata", strconv.Itoa(chapterIndex), "-map_chapters", strconv.Itoa(chapterIndex))
+	}
+	if v.vcodec != "" {
+		cmdline = append(cmdline, "-c:v", v.vcodec)
+	}
+	if v.acodec != "" {
+		cmdline = append(cmdline, "-c:a", v.acodec)
+	}
+	cmdline = append(cmdline, "-strict", "-2")
+	if v.progressFunc != nil {
+		cmdline = append(cmdline, "-progress", "pipe:1", "-nostats")
 	}
-	cmdline = append(cmdline, additionalArgs...)
-	cmdline = append(cmdline, "-vf", filters, "-strict", "-2")
 	cmdline = append(cmdline, output)
 	return cmdline
 }
@@ -260,7 +348,7 @@ func (v *Video) SetBitrate(bitrate int) {
 func (v *Video) SetSize(width int, height int) {
 	v.width = width
 	v.height = height
-	v.filters = append(v.filters, fmt.Sprintf("scale=%d:%d", width, height))
+	v.filters.AddVideo(fmt.Sprintf("scale%s=%d:%d", v.hwaccelFilterSuffix(), width, height))
 }
 
 // Width returns the width of the video in pixels.
@@ -278,10 +366,7 @@ func (v *Video) Height() int {
 func (v *Video) Crop(x, y, width, height int) {
 	v.width = width
 	v.height = height
-	v.filters = append(
-		v.filters,
-		fmt.Sprintf("crop=%d:%d:%d:%d", width, height, x, y),
-	)
+	v.filters.AddVideo(fmt.Sprintf("crop=%d:%d:%d:%d", width, height, x, y))
 }
 
 // Filepath returns the path of the input video.
@@ -311,7 +396,7 @@ func (v *Video) Bitrate() int {
 // Provide a list of absolute or relative paths to these videos by videoPath.
 func NewClip(videoPath []string) (*Clip, error) {
 	var clip Clip
-	if _, err := exec.LookPath("ffprobe"); err != nil {
+	if _, err := exec.LookPath(locateFFprobe()); err != nil {
 		return nil, errors.New("cinema.Load: ffprobe was not found in your PATH " +
 			"environment variable, make sure to install ffmpeg " +
 			"(https://ffmpeg.org/) and add ffmpeg, ffplay and ffprobe to your " +
@@ -336,32 +421,49 @@ func (c *Clip) Concatenate(output string) error {
 	return c.ConcatenateWithStreams(output, nil, nil)
 }
 
+// ConcatenateContext is like Concatenate but the render can be cancelled, or
+// timed out, via ctx. Once ctx is done, the underlying ffmpeg process is
+// killed and ConcatenateContext returns an error wrapping ctx.Err().
+func (c *Clip) ConcatenateContext(ctx context.Context, output string) error {
+	return c.ConcatenateWithStreamsContext(ctx, output, nil, nil)
+}
+
 // ConcatenateWithStreams produces a single video clip based on Clip.videosPath and save it as output.
 // By specifying an output stream and an error stream, you can read ffmpeg's stdout and stderr.
 func (c *Clip) ConcatenateWithStreams(output string, os io.Writer, es io.Writer) error {
+	return c.ConcatenateWithStreamsContext(context.Background(), output, os, es)
+}
+
+// ConcatenateWithStreamsContext combines ConcatenateWithStreams and
+// ConcatenateContext: the render can be cancelled via ctx, and ffmpeg's
+// stdout/stderr are forwarded to os/es.
+func (c *Clip) ConcatenateWithStreamsContext(ctx context.Context, output string, os io.Writer, es io.Writer) error {
 	c.saveConcatenateList()
 	defer c.deleteConcatenateList()
 	line := c.CommandLine(output)
-	cmd := exec.Command(line[0], line[1:]...)
+	cmd := exec.CommandContext(ctx, line[0], line[1:]...)
 	cmd.Stderr = es
 	cmd.Stdout = os
 
-	err := cmd.Run()
-	if err != nil {
-		return errors.New("cinema.Video.Concatenate: ffmpeg failed: " + err.Error())
+	if err := cmd.Run(); err != nil {
+		return wrapContextErr(ctx, "cinema.Video.Concatenate", err)
 	}
 	return nil
 }
 
 // CommandLine returns the command line instruction that will be used to concatenate the video files.
 func (c *Clip) CommandLine(output string) []string {
-	cmdline := []string{
-		"ffmpeg",
-		"-y",
+	cfg := getConfig()
+	cmdline := []string{locateFFmpeg(c.ffmpegPath), "-y"}
+	if cfg.LogLevel != "" {
+		cmdline = append(cmdline, "-v", cfg.LogLevel)
+	}
+	cmdline = append(cmdline, cfg.DefaultArgs...)
+	cmdline = append(cmdline,
 		"-f", "concat",
 		"-i", c.concatListCache,
 		"-c", "copy",
-	}
+	)
 	cmdline = append(cmdline, "-fflags", "+genpts", filepath.Join(filepath.Dir(c.videosPath[0]), output))
 	return cmdline
 }
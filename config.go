@@ -0,0 +1,114 @@
+package cinema
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// Config holds package-wide defaults for locating and invoking ffmpeg and
+// ffprobe. Set it once at startup with SetConfig.
+type Config struct {
+	// FFmpegPath and FFprobePath, if set, are used to locate the respective
+	// binaries in place of searching $PATH. $CINEMA_FFMPEG/$CINEMA_FFPROBE
+	// take precedence over them; see locate for the full resolution order.
+	FFmpegPath  string
+	FFprobePath string
+	// DefaultArgs are prepended to every ffmpeg invocation, before any
+	// per-Video/Clip arguments.
+	DefaultArgs []string
+	// LogLevel, if set, is passed to ffmpeg as "-v LogLevel". ffprobe is
+	// always run with "-v quiet".
+	LogLevel string
+}
+
+var (
+	configMu      sync.RWMutex
+	currentConfig Config
+
+	resolveMu    sync.Mutex
+	resolvedPath = map[string]string{}
+)
+
+// SetConfig replaces the package-wide Config used to locate and invoke
+// ffmpeg/ffprobe and to build their command lines. Call it once during
+// startup, before any Video/Clip is used.
+func SetConfig(cfg Config) {
+	configMu.Lock()
+	currentConfig = cfg
+	configMu.Unlock()
+
+	resolveMu.Lock()
+	resolvedPath = map[string]string{}
+	resolveMu.Unlock()
+}
+
+func getConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return currentConfig
+}
+
+// locateFFmpeg resolves the path to the ffmpeg binary, preferring override
+// (a per-Video/Clip WithBinary path) when set.
+func locateFFmpeg(override string) string {
+	if override != "" {
+		return override
+	}
+	return locate("ffmpeg", "CINEMA_FFMPEG", getConfig().FFmpegPath)
+}
+
+// locateFFprobe resolves the path to the ffprobe binary.
+func locateFFprobe() string {
+	return locate("ffprobe", "CINEMA_FFPROBE", getConfig().FFprobePath)
+}
+
+// locate resolves name (e.g. "ffmpeg") to a path, searching in order:
+// $envVar, the configured path, $PATH (exec.LookPath), and finally the
+// current working directory -- matching how navidrome resolves its ffmpeg
+// dependency. $envVar and the configured path are returned as given; if
+// nothing is found, name itself is returned. The result is cached per name.
+func locate(name, envVar, configured string) string {
+	resolveMu.Lock()
+	defer resolveMu.Unlock()
+	if p, ok := resolvedPath[name]; ok {
+		return p
+	}
+
+	resolved := name
+	switch {
+	case os.Getenv(envVar) != "":
+		resolved = os.Getenv(envVar)
+	case configured != "":
+		resolved = configured
+	default:
+		if p, err := exec.LookPath(name); err == nil {
+			resolved = p
+		} else if wd, err := os.Getwd(); err == nil {
+			if candidate := filepath.Join(wd, name); fileExists(candidate) {
+				resolved = candidate
+			}
+		}
+	}
+
+	resolvedPath[name] = resolved
+	return resolved
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// WithBinary overrides the ffmpeg binary used to render this Video, instead
+// of the one resolved via SetConfig/$CINEMA_FFMPEG/$PATH.
+func (v *Video) WithBinary(path string) {
+	v.ffmpegPath = path
+}
+
+// WithBinary overrides the ffmpeg binary used to concatenate this Clip,
+// instead of the one resolved via SetConfig/$CINEMA_FFMPEG/$PATH.
+func (c *Clip) WithBinary(path string) {
+	c.ffmpegPath = path
+}
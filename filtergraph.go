@@ -0,0 +1,129 @@
+package cinema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilterGraph holds the video (-vf) and audio (-af) filter chains that will
+// be applied when rendering a Video. Filters are appended in the order the
+// corresponding methods (SetSize, Crop, Denoise, Volume, ...) are called.
+type FilterGraph struct {
+	video []string
+	audio []string
+}
+
+// AddVideo appends a filter to the video (-vf) chain.
+func (g *FilterGraph) AddVideo(filter string) {
+	g.video = append(g.video, filter)
+}
+
+// AddAudio appends a filter to the audio (-af) chain.
+func (g *FilterGraph) AddAudio(filter string) {
+	g.audio = append(g.audio, filter)
+}
+
+// VideoChain joins the video filters into a single -vf argument.
+func (g *FilterGraph) VideoChain() string {
+	return strings.Join(g.video, ",")
+}
+
+// AudioChain joins the audio filters into a single -af argument.
+func (g *FilterGraph) AudioChain() string {
+	return strings.Join(g.audio, ",")
+}
+
+// hwaccelFilterSuffix returns the filter name suffix (e.g. "_cuda") that
+// hardware-accelerated filters need for the configured HWAccel, or "" when
+// v has no HWAccel set or the accelerator doesn't rename filters.
+func (v *Video) hwaccelFilterSuffix() string {
+	switch v.hwaccel {
+	case "cuda":
+		return "_cuda"
+	case "vaapi":
+		return "_vaapi"
+	default:
+		return ""
+	}
+}
+
+// SetHWAccel enables ffmpeg hardware-accelerated decoding/filtering, e.g.
+// SetHWAccel("cuda"), SetHWAccel("videotoolbox") or SetHWAccel("vaapi"). It
+// causes CommandLine to emit "-hwaccel" (and, for cuda and vaapi,
+// "-hwaccel_output_format cuda" or "-hwaccel_output_format vaapi") and makes
+// subsequent scaling filters use the accelerator-specific
+// variant (e.g. "scale_cuda").
+func (v *Video) SetHWAccel(accel string) {
+	v.hwaccel = accel
+}
+
+// Codec sets the output video and audio codecs (ffmpeg's "-c:v"/"-c:a"). Pass
+// "" for either argument to leave ffmpeg's default for that stream unchanged.
+func (v *Video) Codec(vcodec, acodec string) {
+	v.vcodec = vcodec
+	v.acodec = acodec
+}
+
+// Volume adjusts the audio volume of the output video by db decibels, e.g.
+// Volume(-6) to quiet the audio down by 6dB.
+func (v *Video) Volume(db float64) {
+	v.filters.AddAudio("volume=" + strconv.FormatFloat(db, 'f', -1, 64) + "dB")
+}
+
+// AudioFade fades the audio in over the first `in` of the output and fades it
+// out over the last `out` of the output. Pass 0 to skip either fade.
+func (v *Video) AudioFade(in, out time.Duration) {
+	if in > 0 {
+		// Filters see the input's timeline (-ss is an output option), so the
+		// fades are positioned relative to v.start/v.end rather than 0.
+		v.filters.AddAudio(fmt.Sprintf("afade=t=in:st=%s:d=%s", formatSeconds(v.start), formatSeconds(in)))
+	}
+	if out > 0 {
+		start := v.end - out
+		if start < v.start {
+			start = v.start
+		}
+		v.filters.AddAudio(fmt.Sprintf("afade=t=out:st=%s:d=%s", formatSeconds(start), formatSeconds(out)))
+	}
+}
+
+// Denoise applies a light spatial/temporal denoise filter (hqdn3d) to the
+// output video.
+func (v *Video) Denoise() {
+	v.filters.AddVideo("hqdn3d")
+}
+
+// Deinterlace deinterlaces the output video using yadif.
+func (v *Video) Deinterlace() {
+	v.filters.AddVideo("yadif")
+}
+
+// Rotate rotates the output video by deg degrees, which must be a multiple of
+// 90 (90, 180 or 270; negative values are also accepted).
+func (v *Video) Rotate(deg int) {
+	switch ((deg % 360) + 360) % 360 {
+	case 90:
+		v.filters.AddVideo("transpose=1")
+	case 180:
+		v.filters.AddVideo("transpose=1,transpose=1")
+	case 270:
+		v.filters.AddVideo("transpose=2")
+	}
+}
+
+// Overlay composites the image or video at path on top of the output video
+// at pixel offset (x, y). Only a single overlay is supported; calling
+// Overlay again replaces the previous one.
+func (v *Video) Overlay(path string, x, y int) {
+	v.overlayPath = path
+	v.overlayX = x
+	v.overlayY = y
+}
+
+// formatSeconds formats d the way cinema passes durations to ffmpeg (seconds
+// as a decimal, e.g. for -ss/-t).
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
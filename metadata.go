@@ -0,0 +1,154 @@
+package cinema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// chapterEntry is a single chapter added with Video.AddChapter.
+type chapterEntry struct {
+	start time.Duration
+	end   time.Duration
+	title string
+}
+
+// StripMetadata removes all container and chapter metadata from the output,
+// and makes encoding bit-exact (no encoder name/date/etc. embedded in the
+// stream), so the render doesn't leak information about the source file.
+func (v *Video) StripMetadata() {
+	v.additionalArgs = append(v.additionalArgs,
+		"-map_metadata", "-1",
+		"-map_chapters", "-1",
+		"-fflags", "+bitexact",
+		"-flags:v", "+bitexact",
+		"-flags:a", "+bitexact",
+	)
+}
+
+// SetMetadata sets container-level metadata key/value pairs on the output
+// (ffmpeg's "-metadata key=value").
+func (v *Video) SetMetadata(tags map[string]string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v.additionalArgs = append(v.additionalArgs, "-metadata", fmt.Sprintf("%s=%s", k, tags[k]))
+	}
+}
+
+// AddChapter adds a chapter spanning [start, end) with the given title. The
+// chapters are written to an ffmetadata file and merged into the output at
+// render time.
+func (v *Video) AddChapter(start, end time.Duration, title string) {
+	v.chapters = append(v.chapters, chapterEntry{start: start, end: end, title: title})
+}
+
+// saveChapterMetadata writes v.chapters to a uniquely-named ffmetadata file
+// next to the input and records the path in v.chapterMetaPath for
+// CommandLine to pick up as an extra -i. The name is unique per call (via
+// os.CreateTemp) rather than a fixed "chapters.ffmeta", so concurrent
+// renders of clones of the same Video -- e.g. RenderParallel's segments --
+// don't race on reading/writing/deleting each other's file.
+func (v *Video) saveChapterMetadata() error {
+	f, err := os.CreateTemp(filepath.Dir(v.filepath), "chapters-*.ffmeta")
+	if err != nil {
+		return errors.New("cinema.Video.AddChapter: unable to write chapter metadata: " + err.Error())
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, ";FFMETADATA1")
+	for _, c := range v.chapters {
+		fmt.Fprintln(f, "[CHAPTER]")
+		fmt.Fprintln(f, "TIMEBASE=1/1000")
+		fmt.Fprintf(f, "START=%d\n", c.start.Milliseconds())
+		fmt.Fprintf(f, "END=%d\n", c.end.Milliseconds())
+		fmt.Fprintf(f, "title=%s\n", ffmetadataEscape(c.title))
+	}
+
+	v.chapterMetaPath = f.Name()
+	return nil
+}
+
+// ffmetadataEscape backslash-escapes the characters ffmpeg's ffmetadata
+// format treats as special ('=', ';', '#', '\' and newlines), per
+// https://ffmpeg.org/ffmpeg-formats.html#Metadata-1, so chapter titles
+// containing them round-trip instead of corrupting the file.
+func ffmetadataEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\', '=', ';', '#', '\n':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// deleteChapterMetadata removes the ffmetadata file written by
+// saveChapterMetadata.
+func (v *Video) deleteChapterMetadata() error {
+	path := v.chapterMetaPath
+	v.chapterMetaPath = ""
+	return os.Remove(path)
+}
+
+// SubtitleStyle configures how Video.BurnSubtitles renders subtitle text, as
+// passed to ffmpeg's "subtitles" filter force_style option.
+type SubtitleStyle struct {
+	// FontName is the subtitle font family, e.g. "Arial".
+	FontName string
+	// FontSize is the subtitle font size in points.
+	FontSize int
+	// PrimaryColour is an ASS &HAABBGGRR color, e.g. "&H00FFFFFF" for opaque white.
+	PrimaryColour string
+}
+
+// forceStyle renders s as ffmpeg's force_style=... value, omitting fields
+// that weren't set.
+func (s SubtitleStyle) forceStyle() string {
+	var parts []string
+	if s.FontName != "" {
+		parts = append(parts, "FontName="+s.FontName)
+	}
+	if s.FontSize != 0 {
+		parts = append(parts, fmt.Sprintf("FontSize=%d", s.FontSize))
+	}
+	if s.PrimaryColour != "" {
+		parts = append(parts, "PrimaryColour="+s.PrimaryColour)
+	}
+	var out string
+	for i, p := range parts {
+		if i > 0 {
+			out += ","
+		}
+		out += p
+	}
+	return out
+}
+
+// BurnSubtitles hard-codes (burns) the subtitle track at path into the
+// output video frames, styled by style.
+func (v *Video) BurnSubtitles(path string, style SubtitleStyle) {
+	filter := fmt.Sprintf("subtitles=%s", path)
+	if forceStyle := style.forceStyle(); forceStyle != "" {
+		filter += fmt.Sprintf(":force_style='%s'", forceStyle)
+	}
+	v.filters.AddVideo(filter)
+}
+
+// MuxSubtitles soft-muxes the subtitle track at path into the output as a
+// separate, selectable stream tagged with lang (an ISO 639-2 code, e.g.
+// "eng"). Only a single soft-muxed subtitle track is supported; calling
+// MuxSubtitles again replaces the previous one.
+func (v *Video) MuxSubtitles(path string, lang string) {
+	v.subtitlePath = path
+	v.subtitleLang = lang
+}
@@ -0,0 +1,236 @@
+package cinema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Format selects the adaptive bitrate packaging format produced by Packager.
+type Format int
+
+const (
+	// HLS packages variants as an HTTP Live Streaming playlist (.m3u8 + .ts segments).
+	HLS Format = iota
+	// DASH packages variants as an MPEG-DASH manifest (.mpd + .m4s segments).
+	DASH
+)
+
+// VariantOpts describes how a single rendition is advertised in the master
+// playlist/manifest produced by Packager.
+type VariantOpts struct {
+	// BandwidthHint is the advertised peak bitrate in bits per second
+	// (the BANDWIDTH attribute for HLS, the bandwidth attribute for DASH).
+	// If 0, the variant's Video.Bitrate() is used instead.
+	BandwidthHint int
+}
+
+// variant is a Video paired with the VariantOpts it was added with.
+type variant struct {
+	video *Video
+	opts  VariantOpts
+}
+
+// Packager produces an HLS or MPEG-DASH adaptive bitrate package from one or
+// more variant Videos. Create one with NewPackager, add renditions with
+// AddVariant, then call Write to run ffmpeg and produce the segments and
+// master playlist/manifest.
+type Packager struct {
+	format          Format
+	variants        []variant
+	segmentDuration time.Duration
+	keyInfoPath     string
+}
+
+// NewPackager returns a Packager that will produce the given Format.
+func NewPackager(format Format) *Packager {
+	return &Packager{
+		format:          format,
+		segmentDuration: 6 * time.Second,
+	}
+}
+
+// AddVariant adds a rendition to the package. video's Trim/SetSize/SetBitrate
+// etc. determine the variant's resolution and bitrate.
+func (p *Packager) AddVariant(video *Video, opts VariantOpts) {
+	p.variants = append(p.variants, variant{video: video, opts: opts})
+}
+
+// SetSegmentDuration sets the target duration of each HLS/DASH segment.
+func (p *Packager) SetSegmentDuration(d time.Duration) {
+	p.segmentDuration = d
+}
+
+// SetKeyInfo enables AES-128 segment encryption (HLS only) using the
+// ffmpeg .keyinfo file at path. See ffmpeg's -hls_key_info_file for the file
+// format (key URI, key file path, and optional IV).
+func (p *Packager) SetKeyInfo(path string) {
+	p.keyInfoPath = path
+}
+
+// Write runs ffmpeg once per variant to produce its segments, then writes the
+// master playlist (HLS) or manifest (DASH) enumerating all variants into dir.
+func (p *Packager) Write(dir string) error {
+	if len(p.variants) == 0 {
+		return errors.New("cinema.Packager.Write: no variants added, call AddVariant first")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.New("cinema.Packager.Write: unable to create output directory: " + err.Error())
+	}
+
+	for i, vr := range p.variants {
+		line := p.variantCommandLine(dir, i, vr)
+		cmd := exec.Command(line[0], line[1:]...)
+		if err := cmd.Run(); err != nil {
+			return errors.New("cinema.Packager.Write: ffmpeg failed: " + err.Error())
+		}
+	}
+
+	switch p.format {
+	case HLS:
+		return p.writeMasterPlaylist(dir)
+	case DASH:
+		return p.writeManifest(dir)
+	default:
+		return errors.New("cinema.Packager.Write: unknown Format")
+	}
+}
+
+// variantCommandLine returns the ffmpeg command line used to segment variant
+// index i of the package into dir.
+func (p *Packager) variantCommandLine(dir string, i int, vr variant) []string {
+	name := fmt.Sprintf("variant_%d", i)
+	line := vr.video.CommandLine(filepath.Join(dir, name+p.segmentExt()))
+
+	// Drop the trailing output path; we rebuild the muxer-specific tail below.
+	line = line[:len(line)-1]
+
+	switch p.format {
+	case HLS:
+		line = append(line,
+			"-f", "hls",
+			"-hls_time", strconv.FormatFloat(p.segmentDuration.Seconds(), 'f', -1, 64),
+			// hls_list_size defaults to 5, which truncates the playlist to a
+			// rolling live window; Packager always produces a complete VOD
+			// asset, so keep every segment and mark the playlist as such.
+			"-hls_list_size", "0",
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(dir, name+"_%03d.ts"),
+		)
+		if p.keyInfoPath != "" {
+			line = append(line, "-hls_key_info_file", p.keyInfoPath)
+		}
+		line = append(line, filepath.Join(dir, name+".m3u8"))
+	case DASH:
+		line = append(line,
+			// -seg_duration is a dash-muxer-only AVOption; the hls muxer
+			// doesn't recognize it and ffmpeg would exit non-zero.
+			"-seg_duration", strconv.FormatFloat(p.segmentDuration.Seconds(), 'f', -1, 64),
+			"-f", "dash",
+			"-init_seg_name", name+"_init.m4s",
+			"-media_seg_name", name+"_$Number$.m4s",
+			filepath.Join(dir, name+".mpd"),
+		)
+	}
+	return line
+}
+
+func (p *Packager) segmentExt() string {
+	if p.format == DASH {
+		return ".m4s"
+	}
+	return ".ts"
+}
+
+// videoCodecTag maps a Video.Codec vcodec argument to the RFC 6381 codec
+// string HLS/DASH manifests expect. vcodec == "" means the variant left
+// ffmpeg's default video encoder in place, which for the muxers Packager
+// targets is libx264.
+func videoCodecTag(vcodec string) string {
+	switch vcodec {
+	case "", "libx264", "h264":
+		return "avc1.64001f"
+	case "libx265", "hevc":
+		return "hvc1.1.6.L93.90"
+	case "libvpx-vp9", "vp9":
+		return "vp09.00.10.08"
+	case "libvpx", "vp8":
+		return "vp08.00.10.08"
+	case "libaom-av1", "av1":
+		return "av01.0.04M.08"
+	default:
+		return vcodec
+	}
+}
+
+// audioCodecTag maps a Video.Codec acodec argument to the RFC 6381 codec
+// string HLS/DASH manifests expect. acodec == "" means the variant left
+// ffmpeg's default audio encoder in place, which for the muxers Packager
+// targets is aac.
+func audioCodecTag(acodec string) string {
+	switch acodec {
+	case "", "aac":
+		return "mp4a.40.2"
+	case "libmp3lame", "mp3":
+		return "mp4a.40.34"
+	case "libopus", "opus":
+		return "opus"
+	default:
+		return acodec
+	}
+}
+
+// writeMasterPlaylist writes the HLS master playlist enumerating every
+// variant with its BANDWIDTH, RESOLUTION and CODECS attributes.
+func (p *Packager) writeMasterPlaylist(dir string) error {
+	f, err := os.Create(filepath.Join(dir, "master.m3u8"))
+	if err != nil {
+		return errors.New("cinema.Packager.Write: unable to create master playlist: " + err.Error())
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, "#EXTM3U")
+	fmt.Fprintln(f, "#EXT-X-VERSION:3")
+	for i, vr := range p.variants {
+		bandwidth := vr.opts.BandwidthHint
+		if bandwidth == 0 {
+			bandwidth = vr.video.Bitrate()
+		}
+		fmt.Fprintf(f, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s,%s\"\n",
+			bandwidth, vr.video.Width(), vr.video.Height(),
+			videoCodecTag(vr.video.vcodec), audioCodecTag(vr.video.acodec))
+		fmt.Fprintf(f, "variant_%d.m3u8\n", i)
+	}
+	return nil
+}
+
+// writeManifest writes a DASH manifest enumerating every variant as an
+// AdaptationSet/Representation pair.
+func (p *Packager) writeManifest(dir string) error {
+	f, err := os.Create(filepath.Join(dir, "manifest.mpd"))
+	if err != nil {
+		return errors.New("cinema.Packager.Write: unable to create manifest: " + err.Error())
+	}
+	defer f.Close()
+
+	fmt.Fprintln(f, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(f, `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" profiles="urn:mpeg:dash:profile:isoff-live:2011" type="static">`)
+	fmt.Fprintln(f, "  <Period>")
+	fmt.Fprintln(f, `    <AdaptationSet mimeType="video/mp4" segmentAlignment="true">`)
+	for i, vr := range p.variants {
+		bandwidth := vr.opts.BandwidthHint
+		if bandwidth == 0 {
+			bandwidth = vr.video.Bitrate()
+		}
+		fmt.Fprintf(f, `      <Representation id="%d" bandwidth="%d" width="%d" height="%d" codecs="%s"/>`+"\n",
+			i, bandwidth, vr.video.Width(), vr.video.Height(), videoCodecTag(vr.video.vcodec))
+	}
+	fmt.Fprintln(f, "    </AdaptationSet>")
+	fmt.Fprintln(f, "  </Period>")
+	fmt.Fprintln(f, "</MPD>")
+	return nil
+}
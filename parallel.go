@@ -0,0 +1,200 @@
+package cinema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Keyframes returns the presentation timestamps of every keyframe (I-frame)
+// in the input video, in ascending order, by asking ffprobe to skip all
+// other frames.
+func (v *Video) Keyframes() ([]time.Duration, error) {
+	cmd := exec.Command(
+		locateFFprobe(),
+		"-v", "quiet",
+		"-skip_frame", "nokey",
+		"-select_streams", "v",
+		"-show_frames",
+		"-show_entries", "frame=pkt_pts_time",
+		"-print_format", "csv=p=0",
+		v.filepath,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New("cinema.Video.Keyframes: ffprobe failed: " + err.Error())
+	}
+
+	var keyframes []time.Duration
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		secs, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			continue
+		}
+		keyframes = append(keyframes, time.Duration(secs*float64(time.Second)))
+	}
+	return keyframes, nil
+}
+
+// snapToKeyframe returns the latest keyframe <= target, or floor if there is
+// none (so boundaries never move earlier than the previous segment's start).
+func snapToKeyframe(keyframes []time.Duration, target, floor time.Duration) time.Duration {
+	best := floor
+	for _, k := range keyframes {
+		if k <= target && k > best {
+			best = k
+		}
+	}
+	return best
+}
+
+// hasKeyframeIn reports whether any keyframe falls within [start, end).
+func hasKeyframeIn(keyframes []time.Duration, start, end time.Duration) bool {
+	for _, k := range keyframes {
+		if k >= start && k < end {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of v whose slice fields don't alias v's, so that
+// concurrent segments rendered from it can be mutated (e.g. Trim,
+// additionalArgs) independently.
+func (v *Video) clone() *Video {
+	c := *v
+	c.additionalArgs = append([]string(nil), v.additionalArgs...)
+	c.filters.video = append([]string(nil), v.filters.video...)
+	c.filters.audio = append([]string(nil), v.filters.audio...)
+	c.chapters = append([]chapterEntry(nil), v.chapters...)
+	return &c
+}
+
+// RenderParallel is like Render, but it splits the trimmed range
+// [v.Start(), v.End()) into `workers` segments aligned to the nearest
+// preceding keyframe, encodes each segment concurrently with the rest of v's
+// settings (filters, codec, bitrate, ...), and stitches the results back
+// together with Clip.Concatenate. This can be significantly faster than
+// Render for CPU-bound encodes on multi-core machines. Temp files are always
+// cleaned up, including when an error occurs.
+func (v *Video) RenderParallel(output string, workers int) error {
+	if workers < 2 {
+		return v.Render(output)
+	}
+
+	keyframes, err := v.Keyframes()
+	if err != nil {
+		return err
+	}
+
+	total := v.end - v.start
+	step := total / time.Duration(workers)
+	bounds := make([]time.Duration, workers+1)
+	bounds[0] = v.start
+	for i := 1; i < workers; i++ {
+		target := v.start + step*time.Duration(i)
+		bounds[i] = snapToKeyframe(keyframes, target, bounds[i-1])
+	}
+	bounds[workers] = v.end
+
+	tmpDir, err := os.MkdirTemp("", "cinema-renderparallel-")
+	if err != nil {
+		return errors.New("cinema.Video.RenderParallel: unable to create temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(tmpDir)
+
+	segmentCount := 0
+	for i := 0; i < workers; i++ {
+		if bounds[i] < bounds[i+1] {
+			segmentCount++
+		}
+	}
+	paths := make([]string, segmentCount)
+	errs := make([]error, segmentCount)
+
+	var wg sync.WaitGroup
+	seg := 0
+	for i := 0; i < workers; i++ {
+		start, end := bounds[i], bounds[i+1]
+		if start >= end {
+			continue
+		}
+		i, seg := i, seg
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			segment := v.clone()
+			segment.SetStart(start)
+			segment.SetEnd(end)
+			if !hasKeyframeIn(keyframes, start, end) {
+				segment.additionalArgs = append(segment.additionalArgs, "-force_key_frames", formatSeconds(start))
+			}
+			path := filepath.Join(tmpDir, fmt.Sprintf("segment_%03d.mp4", i))
+			if err := segment.Render(path); err != nil {
+				errs[seg] = err
+				return
+			}
+			paths[seg] = path
+		}()
+		seg++
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	clip, err := NewClip(paths)
+	if err != nil {
+		return err
+	}
+	// Clip.Concatenate writes relative to the directory of its first input,
+	// which is tmpDir here, so stitch into a tmpDir-local name and then move
+	// the result to the caller's requested output path.
+	concatName := filepath.Base(output)
+	if err := clip.Concatenate(concatName); err != nil {
+		return err
+	}
+	return moveFile(filepath.Join(tmpDir, concatName), output)
+}
+
+// moveFile moves src to dst, falling back to a copy+remove when os.Rename
+// fails (e.g. "invalid cross-device link" because tmpDir and output live on
+// different filesystems, the common case in containers).
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,192 @@
+package cinema
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"time"
+)
+
+// StreamInfo describes a single stream (video, audio, subtitle, ...) reported
+// by ffprobe.
+type StreamInfo struct {
+	Index       int
+	CodecType   string // "video", "audio", "subtitle", ...
+	CodecName   string
+	PixFmt      string
+	Width       int
+	Height      int
+	SampleRate  int
+	Channels    int
+	RFrameRate  string
+	BitRate     int64
+	Tags        map[string]string
+	Disposition map[string]int
+}
+
+// FormatInfo describes the container reported by ffprobe.
+type FormatInfo struct {
+	Filename   string
+	FormatName string
+	Duration   time.Duration
+	BitRate    int64
+	Tags       map[string]string
+	Chapters   []ChapterInfo
+}
+
+// ChapterInfo describes a single chapter reported by ffprobe.
+type ChapterInfo struct {
+	ID    int64
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+// MediaInfo is the structured result of Probe.
+type MediaInfo struct {
+	Streams []StreamInfo
+	Format  FormatInfo
+}
+
+// VideoStream returns the first video stream, or nil if there isn't one.
+func (mi *MediaInfo) VideoStream() *StreamInfo {
+	for i := range mi.Streams {
+		if mi.Streams[i].CodecType == "video" {
+			return &mi.Streams[i]
+		}
+	}
+	return nil
+}
+
+// AudioStream returns the first audio stream, or nil if there isn't one.
+func (mi *MediaInfo) AudioStream() *StreamInfo {
+	for i := range mi.Streams {
+		if mi.Streams[i].CodecType == "audio" {
+			return &mi.Streams[i]
+		}
+	}
+	return nil
+}
+
+// HasStream reports whether any stream uses the given codec name, e.g.
+// mi.HasStream("h264").
+func (mi *MediaInfo) HasStream(codec string) bool {
+	for _, s := range mi.Streams {
+		if s.CodecName == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// AspectRatio returns width/height of the first video stream's coded
+// dimensions, or 0 if there isn't one or its height is 0. The sample aspect
+// ratio and any rotation tag are not taken into account.
+func (mi *MediaInfo) AspectRatio() float32 {
+	vs := mi.VideoStream()
+	if vs == nil || vs.Height == 0 {
+		return 0
+	}
+	return float32(vs.Width) / float32(vs.Height)
+}
+
+// rawProbe mirrors ffprobe's "-print_format json -show_format -show_streams
+// -show_chapters" output closely enough to unmarshal it; numeric fields that ffprobe quotes
+// as strings use json.Number so they parse either way.
+type rawProbe struct {
+	Streams []struct {
+		Index       int               `json:"index"`
+		CodecType   string            `json:"codec_type"`
+		CodecName   string            `json:"codec_name"`
+		PixFmt      string            `json:"pix_fmt"`
+		Width       int               `json:"width"`
+		Height      int               `json:"height"`
+		SampleRate  json.Number       `json:"sample_rate"`
+		Channels    int               `json:"channels"`
+		RFrameRate  string            `json:"r_frame_rate"`
+		BitRate     json.Number       `json:"bit_rate"`
+		Tags        map[string]string `json:"tags"`
+		Disposition map[string]int    `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		Filename    string            `json:"filename"`
+		FormatName  string            `json:"format_name"`
+		DurationSec json.Number       `json:"duration"`
+		BitRate     json.Number       `json:"bit_rate"`
+		Tags        map[string]string `json:"tags"`
+	} `json:"format"`
+	Chapters []struct {
+		ID        int64             `json:"id"`
+		StartTime json.Number       `json:"start_time"`
+		EndTime   json.Number       `json:"end_time"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"chapters"`
+}
+
+// Probe runs ffprobe against path and returns the parsed stream and format
+// metadata. Unlike Load, Probe does not validate the result — it is a thin,
+// typed wrapper around ffprobe's JSON output.
+func Probe(path string) (*MediaInfo, error) {
+	cmd := exec.Command(
+		locateFFprobe(),
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New("cinema.Probe: ffprobe failed: " + err.Error())
+	}
+
+	var raw rawProbe
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, errors.New("cinema.Probe: unable to parse JSON output from ffprobe: " + err.Error())
+	}
+
+	mi := &MediaInfo{Streams: make([]StreamInfo, len(raw.Streams))}
+	for i, s := range raw.Streams {
+		sampleRate, _ := s.SampleRate.Int64()
+		bitRate, _ := s.BitRate.Int64()
+		mi.Streams[i] = StreamInfo{
+			Index:       s.Index,
+			CodecType:   s.CodecType,
+			CodecName:   s.CodecName,
+			PixFmt:      s.PixFmt,
+			Width:       s.Width,
+			Height:      s.Height,
+			SampleRate:  int(sampleRate),
+			Channels:    s.Channels,
+			RFrameRate:  s.RFrameRate,
+			BitRate:     bitRate,
+			Tags:        s.Tags,
+			Disposition: s.Disposition,
+		}
+	}
+
+	durationSecs, _ := raw.Format.DurationSec.Float64()
+	formatBitRate, _ := raw.Format.BitRate.Int64()
+	chapters := make([]ChapterInfo, len(raw.Chapters))
+	for i, c := range raw.Chapters {
+		startSecs, _ := c.StartTime.Float64()
+		endSecs, _ := c.EndTime.Float64()
+		chapters[i] = ChapterInfo{
+			ID:    c.ID,
+			Start: time.Duration(startSecs * float64(time.Second)),
+			End:   time.Duration(endSecs * float64(time.Second)),
+			Title: c.Tags["title"],
+		}
+	}
+	mi.Format = FormatInfo{
+		Filename:   raw.Format.Filename,
+		FormatName: raw.Format.FormatName,
+		Duration:   time.Duration(durationSecs*float64(time.Second) + 0.5),
+		BitRate:    formatBitRate,
+		Tags:       raw.Format.Tags,
+		Chapters:   chapters,
+	}
+
+	return mi, nil
+}
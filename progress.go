@@ -0,0 +1,84 @@
+package cinema
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress describes the state of an in-flight render as reported by
+// ffmpeg's "-progress pipe:1" output.
+type Progress struct {
+	// Time is the current output timestamp.
+	Time time.Duration
+	// Frame is the number of frames encoded so far.
+	Frame int
+	// FPS is the current encoding speed in frames per second.
+	FPS float64
+	// Speed is the encoding speed relative to realtime (e.g. 1.5 means 1.5x).
+	Speed float64
+	// Percent is Time as a fraction of the trimmed duration (v.end - v.start),
+	// in the range [0, 100]. It is 0 if the trimmed duration is 0.
+	Percent float64
+}
+
+// ProgressFunc is called once for every progress update ffmpeg reports while
+// a Video is rendering. See Video.SetProgressFunc.
+type ProgressFunc func(Progress)
+
+// SetProgressFunc registers fn to be called with progress updates while
+// rendering. Passing nil disables progress reporting. When set, Render and
+// RenderContext pass "-progress pipe:1 -nostats" to ffmpeg and parse its
+// key/value output instead of forwarding ffmpeg's stdout to the caller.
+func (v *Video) SetProgressFunc(fn ProgressFunc) {
+	v.progressFunc = fn
+}
+
+// watchProgress reads ffmpeg's "-progress pipe:1" key/value stream from r and
+// invokes fn once per progress report (i.e. once per "progress=continue" or
+// "progress=end" line). total is used to compute Progress.Percent.
+func watchProgress(r io.Reader, total time.Duration, fn ProgressFunc) {
+	var p Progress
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "out_time_us":
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil {
+				p.Time = time.Duration(us) * time.Microsecond
+				if total > 0 {
+					p.Percent = 100 * float64(p.Time) / float64(total)
+					if p.Percent < 0 {
+						p.Percent = 0
+					} else if p.Percent > 100 {
+						p.Percent = 100
+					}
+				}
+			}
+		case "frame":
+			if frame, err := strconv.Atoi(value); err == nil {
+				p.Frame = frame
+			}
+		case "fps":
+			if fps, err := strconv.ParseFloat(value, 64); err == nil {
+				p.FPS = fps
+			}
+		case "speed":
+			if speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+				p.Speed = speed
+			}
+		case "progress":
+			fn(p)
+			if value == "end" {
+				return
+			}
+		}
+	}
+}
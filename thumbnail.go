@@ -0,0 +1,236 @@
+package cinema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ThumbnailOpts configures Video.Thumbnail.
+type ThumbnailOpts struct {
+	// Width and Height resize the thumbnail. If either is 0 the frame is
+	// kept at its original size.
+	Width  int
+	Height int
+}
+
+// frameFilterArgs combines v's existing video filter chain (Crop, Rotate,
+// Denoise, Overlay, ...) with an additional filter so that one-off frame
+// extraction commands (Thumbnail, SpriteSheet, AnimatedPreview) don't
+// silently drop settings already applied to v via CommandLine's plumbing.
+// It returns the extra -i arguments Overlay needs (if any), and the
+// -vf/-filter_complex flag and value to use.
+func (v *Video) frameFilterArgs(filter string) (extraInputArgs []string, filterFlag string, filterValue string) {
+	if base := v.filters.VideoChain(); base != "" {
+		if filter != "" {
+			filter = base + "," + filter
+		} else {
+			filter = base
+		}
+	}
+	if v.overlayPath == "" {
+		return nil, "-vf", filter
+	}
+	return []string{"-i", v.overlayPath}, "-filter_complex",
+		fmt.Sprintf("[0:v]%s[base];[base][1:v]overlay=%d:%d", filter, v.overlayX, v.overlayY)
+}
+
+// ffmpegArgs returns the locator/-v/DefaultArgs prefix shared by every
+// ffmpeg invocation, the same way CommandLine applies Config to Render.
+func (v *Video) ffmpegArgs() []string {
+	cfg := getConfig()
+	args := []string{locateFFmpeg(v.ffmpegPath), "-y"}
+	if cfg.LogLevel != "" {
+		args = append(args, "-v", cfg.LogLevel)
+	}
+	return append(args, cfg.DefaultArgs...)
+}
+
+// Thumbnail extracts a single frame, the first keyframe (I-frame) at or after
+// time t (relative to the original, untrimmed input, like Video.SetStart),
+// and writes it to out as a PNG/JPEG chosen by out's extension.
+func (v *Video) Thumbnail(t time.Duration, out string, opts ThumbnailOpts) error {
+	t = v.clampToDuration(t)
+
+	filter := "select='eq(pict_type\\,I)'"
+	if opts.Width > 0 && opts.Height > 0 {
+		filter += fmt.Sprintf(",scale=%d:%d", opts.Width, opts.Height)
+	}
+	extraInputs, filterFlag, filterValue := v.frameFilterArgs(filter)
+
+	args := v.ffmpegArgs()
+	args = append(args, "-ss", formatSeconds(t), "-i", v.filepath)
+	args = append(args, extraInputs...)
+	args = append(args, "-frames:v", "1", filterFlag, filterValue, out)
+
+	if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+		return errors.New("cinema.Video.Thumbnail: ffmpeg failed: " + err.Error())
+	}
+	return nil
+}
+
+// SpriteMeta describes a sprite sheet produced by Video.SpriteSheet, so
+// callers can map mouse position / scrub time back to a tile without
+// re-deriving the grid layout.
+type SpriteMeta struct {
+	Path       string  `json:"path"`
+	Columns    int     `json:"columns"`
+	Rows       int     `json:"rows"`
+	TileWidth  int     `json:"tile_width"`
+	TileHeight int     `json:"tile_height"`
+	Interval   float64 `json:"interval_seconds"`
+	Count      int     `json:"count"`
+}
+
+// SpriteSheet samples one frame every interval across the whole input and
+// tiles them into a cols x rows grid image written to out, for building a
+// scrubbing preview the way video players do. A JSON index describing the
+// grid is written alongside it at out + ".json".
+func (v *Video) SpriteSheet(interval time.Duration, cols, rows int, out string) (*SpriteMeta, error) {
+	if interval <= 0 {
+		return nil, errors.New("cinema.Video.SpriteSheet: interval must be positive")
+	}
+	if cols <= 0 || rows <= 0 {
+		return nil, errors.New("cinema.Video.SpriteSheet: cols and rows must be positive")
+	}
+
+	// tile fills one cols*rows grid: fewer input frames leave the grid
+	// partly empty, more and it silently keeps only the first cols*rows,
+	// covering just the start of the video. Fail instead of producing a
+	// sheet/index that undercounts the timeline.
+	total := v.duration
+	frameCount := int(total/interval) + 1
+	if frameCount > cols*rows {
+		return nil, fmt.Errorf("cinema.Video.SpriteSheet: cols*rows (%d) is too small to "+
+			"cover a %s video sampled every %s; increase cols/rows or interval",
+			cols*rows, total, interval)
+	}
+
+	filter := fmt.Sprintf("fps=1/%s,scale=%d:%d,tile=%dx%d",
+		formatSeconds(interval), v.width, v.height, cols, rows)
+	extraInputs, filterFlag, filterValue := v.frameFilterArgs(filter)
+
+	args := v.ffmpegArgs()
+	args = append(args, "-i", v.filepath)
+	args = append(args, extraInputs...)
+	args = append(args, filterFlag, filterValue, "-frames:v", "1", out)
+
+	if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+		return nil, errors.New("cinema.Video.SpriteSheet: ffmpeg failed: " + err.Error())
+	}
+
+	meta := &SpriteMeta{
+		Path:       out,
+		Columns:    cols,
+		Rows:       rows,
+		TileWidth:  v.width,
+		TileHeight: v.height,
+		Interval:   interval.Seconds(),
+		Count:      frameCount,
+	}
+
+	f, err := os.Create(out + ".json")
+	if err != nil {
+		return nil, errors.New("cinema.Video.SpriteSheet: unable to write sprite index: " + err.Error())
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(meta); err != nil {
+		return nil, errors.New("cinema.Video.SpriteSheet: unable to write sprite index: " + err.Error())
+	}
+	return meta, nil
+}
+
+// PreviewOpts configures Video.AnimatedPreview.
+type PreviewOpts struct {
+	// Clips is the number of samples taken across the timeline. Defaults to
+	// 4 if 0.
+	Clips int
+	// ClipDuration is how long each sample is. Defaults to 1s if 0.
+	ClipDuration time.Duration
+	// Width and Height resize every sample. If either is 0 the samples are
+	// kept at their original size.
+	Width  int
+	Height int
+}
+
+// AnimatedPreview samples opts.Clips clips of opts.ClipDuration spread evenly
+// across [v.Start(), v.End()), crossfades between them, and writes the
+// result to out as an animated WebP/GIF/MP4 chosen by out's extension.
+func (v *Video) AnimatedPreview(out string, opts PreviewOpts) error {
+	clips := opts.Clips
+	if clips <= 0 {
+		clips = 4
+	}
+	clipDur := opts.ClipDuration
+	if clipDur <= 0 {
+		clipDur = 1 * time.Second
+	}
+
+	total := v.end - v.start
+	if total <= 0 {
+		return errors.New("cinema.Video.AnimatedPreview: trimmed duration is 0")
+	}
+
+	xfadeDur := clipDur / 4
+	if xfadeDur <= 0 {
+		xfadeDur = clipDur / 2
+	}
+
+	step := total / time.Duration(clips)
+	perClipFilter := v.filters.VideoChain()
+	if opts.Width > 0 && opts.Height > 0 {
+		scale := fmt.Sprintf("scale=%d:%d", opts.Width, opts.Height)
+		if perClipFilter != "" {
+			perClipFilter += "," + scale
+		} else {
+			perClipFilter = scale
+		}
+	}
+	if perClipFilter != "" {
+		perClipFilter = "," + perClipFilter
+	}
+
+	labels := make([]string, clips)
+	var graph []string
+	for i := 0; i < clips; i++ {
+		start := v.start + step*time.Duration(i)
+		end := start + clipDur
+		if end > v.end {
+			end = v.end
+		}
+		labels[i] = fmt.Sprintf("c%d", i)
+		graph = append(graph, fmt.Sprintf("[0:v]trim=start=%s:end=%s,setpts=PTS-STARTPTS%s[%s]",
+			formatSeconds(start), formatSeconds(end), perClipFilter, labels[i]))
+	}
+
+	cur := labels[0]
+	offset := clipDur - xfadeDur
+	for i := 1; i < clips; i++ {
+		next := fmt.Sprintf("x%d", i)
+		graph = append(graph, fmt.Sprintf("[%s][%s]xfade=transition=fade:duration=%s:offset=%s[%s]",
+			cur, labels[i], formatSeconds(xfadeDur), formatSeconds(offset), next))
+		cur = next
+		offset += clipDur - xfadeDur
+	}
+
+	var extraInputs []string
+	if v.overlayPath != "" {
+		extraInputs = append(extraInputs, "-i", v.overlayPath)
+		graph = append(graph, fmt.Sprintf("[%s][1:v]overlay=%d:%d[final]", cur, v.overlayX, v.overlayY))
+		cur = "final"
+	}
+
+	args := v.ffmpegArgs()
+	args = append(args, "-i", v.filepath)
+	args = append(args, extraInputs...)
+	args = append(args, "-filter_complex", strings.Join(graph, ";"), "-map", "["+cur+"]", out)
+
+	if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+		return errors.New("cinema.Video.AnimatedPreview: ffmpeg failed: " + err.Error())
+	}
+	return nil
+}